@@ -1,176 +1,358 @@
 package core
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
-	"strings"
+	"strconv"
 
 	"github.com/Nerzal/gocloak/models"
-	resty "gopkg.in/resty.v1"
+	"github.com/google/go-querystring/query"
 )
 
 type Client interface {
-	Login(username string, password string, realm string) (*models.JWT, error)
-
-	DirectGrantAuthentication(clientID string, clientSecret string, realm string, username string, password string) (*models.JWT, error)
-	GetUsers(token *models.JWT, realm string) (*[]models.User, error)
-	GetUserGroups(token *models.JWT, realm string, userID string) (*[]models.UserGroup, error)
-	GetRoleMappingByGroupID(token *models.JWT, realm string, groupID string) (*[]models.RoleMapping, error)
-	GetGroups(token *models.JWT, realm string) (*[]models.Group, error)
-	GetRoles(token *models.JWT, realm string) (*[]models.Role, error)
-	GetRolesByClientID(token *models.JWT, realm string, clientID string) (*[]models.Role, error)
-	GetClients(token *models.JWT, realm string) (*[]models.RealmClient, error)
+	Login(ctx context.Context, username string, password string, realm string) (*models.JWT, error)
+
+	DirectGrantAuthentication(ctx context.Context, clientID string, clientSecret string, realm string, username string, password string) (*models.JWT, error)
+	RefreshToken(ctx context.Context, refreshToken string, clientID string, clientSecret string, realm string) (*models.JWT, error)
+	Logout(ctx context.Context, refreshToken string, clientID string, clientSecret string, realm string) error
+	RetrospectToken(ctx context.Context, accessToken string, clientID string, clientSecret string, realm string) (*models.IntrospectTokenResult, error)
+	GetRequestingPartyToken(ctx context.Context, accessToken string, clientID string, clientSecret string, realm string, resourceID string) (*models.JWT, error)
+	GetUsers(ctx context.Context, token *models.JWT, realm string, params models.GetUsersParams) (*[]models.User, error)
+	GetUserCount(ctx context.Context, token *models.JWT, realm string) (int, error)
+	GetUserGroups(ctx context.Context, token *models.JWT, realm string, userID string) (*[]models.UserGroup, error)
+	GetRoleMappingByGroupID(ctx context.Context, token *models.JWT, realm string, groupID string) (*[]models.RoleMapping, error)
+	GetGroups(ctx context.Context, token *models.JWT, realm string, params models.GetGroupsParams) (*[]models.Group, error)
+	GetRoles(ctx context.Context, token *models.JWT, realm string, params models.GetRolesParams) (*[]models.Role, error)
+	GetRolesByClientID(ctx context.Context, token *models.JWT, realm string, clientID string) (*[]models.Role, error)
+	GetClients(ctx context.Context, token *models.JWT, realm string, params models.GetClientsParams) (*[]models.RealmClient, error)
+
+	CreateUser(ctx context.Context, token *models.JWT, realm string, user models.User) error
+	CreateRealm(ctx context.Context, token *models.JWT, realm models.RealmRepresentation) error
+	DeleteRealm(ctx context.Context, token *models.JWT, realm string) error
+	CreateClient(ctx context.Context, token *models.JWT, realm string, newClient models.Client, authType models.ClientAuthType) error
+	DeleteClient(ctx context.Context, token *models.JWT, realm string, clientID string) error
+	GetClientSecret(ctx context.Context, token *models.JWT, realm string, clientID string) (*models.CredentialRepresentation, error)
+	RegenerateClientSecret(ctx context.Context, token *models.JWT, realm string, clientID string) (*models.CredentialRepresentation, error)
+
+	GetRequiredActions(ctx context.Context, token *models.JWT, realm string) (*[]models.RequiredActionProviderRepresentation, error)
+	GetRequiredAction(ctx context.Context, token *models.JWT, realm string, alias string) (*models.RequiredActionProviderRepresentation, error)
+	UpdateRequiredAction(ctx context.Context, token *models.JWT, realm string, action models.RequiredActionProviderRepresentation) error
+	RegisterRequiredAction(ctx context.Context, token *models.JWT, realm string, providerID string, name string) error
+	ExecuteActionsEmail(ctx context.Context, token *models.JWT, realm string, userID string, actions []string, clientID string, redirectURI string, lifespan int) error
 }
 
 type client struct {
-	basePath string
-}
-
-type loginData struct {
-	ClientID  string `json:"client_id"`
-	UserName  string `json:"username"`
-	Password  string `json:"password"`
-	GrantType string `json:"grant_type"`
+	basePath  string
+	transport *Transport
 }
 
 const adminClientID string = "admin-cli"
 
-// NewClient creates a new Client
+// NewClient creates a new Client using a Transport with default retry/backoff settings
 func NewClient(basePath string) Client {
+	return NewClientWithTransport(basePath, NewTransport())
+}
+
+// NewClientWithTransport creates a new Client using a caller-supplied Transport, e.g. one
+// configured with a custom RoundTripper for mTLS or a TokenRefresher for transparent retry
+func NewClientWithTransport(basePath string, transport *Transport) Client {
 	return &client{
-		basePath: basePath,
+		basePath:  basePath,
+		transport: transport,
 	}
 }
 
-// Login performs a login
-func (client *client) Login(username, password, realm string) (*models.JWT, error) {
-	firstPart := "/auth/realms/"
-	lastPart := "/protocol/openid-connect/token"
-	loginPath := firstPart + realm + lastPart
+// NewClientWithAutoRefresh creates a new Client whose Transport transparently refreshes an
+// expired access token using clientID/clientSecret and retries the failed request once, rather
+// than returning the 401 to the caller. Use NewClient (or NewClientWithTransport, supplying a
+// TokenRefresher built around RefreshToken yourself) if that retry-on-401 behavior is undesired.
+func NewClientWithAutoRefresh(basePath string, clientID string, clientSecret string) Client {
+	transport := NewTransport()
+	c := &client{basePath: basePath, transport: transport}
+
+	transport.refresher = func(ctx context.Context, refreshToken string, realm string) (*models.JWT, error) {
+		return c.RefreshToken(ctx, refreshToken, clientID, clientSecret, realm)
+	}
+
+	return c
+}
 
+// Login performs a login
+func (client *client) Login(ctx context.Context, username, password, realm string) (*models.JWT, error) {
 	data := url.Values{}
 	data.Set("client_id", adminClientID)
 	data.Add("grant_type", "password")
 	data.Add("username", username)
 	data.Add("password", password)
 
-	req, _ := http.NewRequest("POST", client.basePath+loginPath, strings.NewReader(data.Encode()))
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	jwt := &models.JWT{}
+	if _, err := client.transport.DoForm(ctx, nil, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/token", data, jwt); err != nil {
+		return nil, err
+	}
+	jwt.Realm = realm
+
+	return jwt, nil
+}
+
+// DirectGrantAuthentication like login, but with basic auth
+func (client *client) DirectGrantAuthentication(ctx context.Context, clientID string, clientSecret string, realm string, username string, password string) (*models.JWT, error) {
+	data := url.Values{}
+	data.Set("grant_type", "password")
+	data.Set("username", username)
+	data.Set("password", password)
 
-	res, err := http.DefaultClient.Do(req)
+	return client.doBasicAuthTokenRequest(ctx, clientID, clientSecret, realm, data)
+}
+
+// RefreshToken uses a refresh token to obtain a new access token
+func (client *client) RefreshToken(ctx context.Context, refreshToken string, clientID string, clientSecret string, realm string) (*models.JWT, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+
+	jwt := &models.JWT{}
+	resp, err := client.transport.DoForm(ctx, nil, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/token", data, jwt)
 	if err != nil {
 		return nil, err
 	}
 
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.New("failed to refresh token: " + resp.Status)
+	}
+	jwt.Realm = realm
+
+	return jwt, nil
+}
+
+// Logout invalidates the refresh token and ends the session it was issued for
+func (client *client) Logout(ctx context.Context, refreshToken string, clientID string, clientSecret string, realm string) error {
+	data := url.Values{}
+	data.Set("refresh_token", refreshToken)
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+
+	resp, err := client.transport.DoForm(ctx, nil, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/logout", data, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if res.StatusCode != 200 {
-		log.Println(string(body))
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to logout: " + resp.Status)
 	}
 
-	jwt := &models.JWT{}
-	err = json.Unmarshal(body, jwt)
-	return jwt, err
+	return nil
 }
 
-// DirectGrantAuthentication like login, but with basic auth
-func (client *client) DirectGrantAuthentication(clientID string, clientSecret string, realm string, username string, password string) (*models.JWT, error) {
-	resp, err := resty.R().
-		SetHeader("Content-Type", "application/x-www-form-urlencoded").
-		SetHeader("Authorization", getBasicAuthForClient(clientID, clientSecret)).
-		SetFormData(map[string]string{
-			"grant_type": "password",
-			"username":   username,
-			"password":   password,
-		}).Post(client.basePath + "/auth/realms/" + realm + "/protocol/openid-connect/token")
+// RetrospectToken calls the token introspection endpoint to validate a token server-side
+func (client *client) RetrospectToken(ctx context.Context, accessToken string, clientID string, clientSecret string, realm string) (*models.IntrospectTokenResult, error) {
+	data := url.Values{}
+	data.Set("token", accessToken)
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+
+	result := &models.IntrospectTokenResult{}
+	if _, err := client.transport.DoForm(ctx, nil, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/token/introspect", data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetRequestingPartyToken exchanges an access token for a UMA 2.0 RPT scoped to resourceID
+func (client *client) GetRequestingPartyToken(ctx context.Context, accessToken string, clientID string, clientSecret string, realm string, resourceID string) (*models.JWT, error) {
+	data := url.Values{}
+	data.Set("grant_type", "urn:ietf:params:oauth:grant-type:uma-ticket")
+	data.Set("audience", clientID)
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+	data.Set("permission", resourceID)
+
+	jwt := &models.JWT{}
+	token := &models.JWT{AccessToken: accessToken}
+	resp, err := client.transport.DoForm(ctx, token, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/token", data, jwt)
 	if err != nil {
 		return nil, err
 	}
 
-	// Here’s the actual decoding, and a check for associated errors.
-	var result map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.New("failed to obtain requesting party token: " + resp.Status)
+	}
+	jwt.Realm = realm
+
+	return jwt, nil
+}
+
+// doBasicAuthTokenRequest posts data to the token endpoint with client basic auth and decodes a JWT
+func (client *client) doBasicAuthTokenRequest(ctx context.Context, clientID string, clientSecret string, realm string, data url.Values) (*models.JWT, error) {
+	jwt := &models.JWT{}
+	resp, err := client.transport.DoFormWithBasicAuth(ctx, clientID, clientSecret, http.MethodPost, client.basePath+"/auth/realms/"+realm+"/protocol/openid-connect/token", data, jwt)
+	if err != nil {
 		return nil, err
 	}
 
-	// Check for Result
-	if val, ok := result["access_token"]; ok {
-		_ = val
-		return &models.JWT{
-			AccessToken:      result["access_token"].(string),
-			ExpiresIn:        result["expires_in"].(int),
-			RefreshExpiresIn: result["refresh_expires_in"].(int),
-			RefreshToken:     result["refresh_token"].(string),
-			TokenType:        result["token_type"].(string),
-		}, nil
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.New("Authentication failed: " + resp.Status)
+	}
+	jwt.Realm = realm
+
+	return jwt, nil
+}
+
+// CreateUser creates a new user in realm
+func (client *client) CreateUser(ctx context.Context, token *models.JWT, realm string, user models.User) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPost, client.basePath+"/auth/admin/realms/"+realm+"/users", nil, user, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return errors.New("failed to create user: " + resp.Status)
+	}
+
+	return nil
+}
+
+// CreateRealm creates a new realm
+func (client *client) CreateRealm(ctx context.Context, token *models.JWT, realm models.RealmRepresentation) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPost, client.basePath+"/auth/admin/realms", nil, realm, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return errors.New("failed to create realm: " + resp.Status)
+	}
+
+	return nil
+}
+
+// DeleteRealm removes a realm
+func (client *client) DeleteRealm(ctx context.Context, token *models.JWT, realm string) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodDelete, client.basePath+"/auth/admin/realms/"+realm, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to delete realm: " + resp.Status)
 	}
 
-	return nil, errors.New("Authentication failed")
+	return nil
 }
 
-func (client *client) CreateUser(token *models.JWT, realm string) error {
+// CreateClient registers a new client in realm. authType selects whether the client
+// authenticates with a shared secret, a signed JWT, or an x509 certificate.
+func (client *client) CreateClient(ctx context.Context, token *models.JWT, realm string, newClient models.Client, authType models.ClientAuthType) error {
+	switch authType {
+	case models.ClientAuthJWT:
+		newClient.ClientAuthenticatorType = string(models.ClientAuthJWT)
+	case models.ClientAuthX509:
+		newClient.ClientAuthenticatorType = string(models.ClientAuthX509)
+		if newClient.Attributes == nil {
+			newClient.Attributes = map[string]string{}
+		}
+		if _, ok := newClient.Attributes["x509.allow.regex.pattern.comparison"]; !ok {
+			newClient.Attributes["x509.allow.regex.pattern.comparison"] = "false"
+		}
+	default:
+		newClient.ClientAuthenticatorType = string(models.ClientAuthSecret)
+	}
+
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPost, client.basePath+"/auth/admin/realms/"+realm+"/clients", nil, newClient, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return errors.New("failed to create client: " + resp.Status)
+	}
+
 	return nil
 }
 
-// GetUsers get all users inr ealm
-func (client *client) GetUsers(token *models.JWT, realm string) (*[]models.User, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/users")
+// DeleteClient removes a client from realm
+func (client *client) DeleteClient(ctx context.Context, token *models.JWT, realm string, clientID string) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodDelete, client.basePath+"/auth/admin/realms/"+realm+"/clients/"+clientID, nil, nil, nil)
 	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to delete client: " + resp.Status)
+	}
+
+	return nil
+}
+
+// GetClientSecret gets the client secret used by client-secret authenticated clients
+func (client *client) GetClientSecret(ctx context.Context, token *models.JWT, realm string, clientID string) (*models.CredentialRepresentation, error) {
+	result := &models.CredentialRepresentation{}
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/clients/"+clientID+"/client-secret", nil, nil, result); err != nil {
 		return nil, err
 	}
 
-	// Decode into struct
-	var result []models.User
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	return result, nil
+}
+
+// RegenerateClientSecret generates a new secret for the client, replacing the old one
+func (client *client) RegenerateClientSecret(ctx context.Context, token *models.JWT, realm string, clientID string) (*models.CredentialRepresentation, error) {
+	result := &models.CredentialRepresentation{}
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodPost, client.basePath+"/auth/admin/realms/"+realm+"/clients/"+clientID+"/client-secret", nil, nil, result); err != nil {
 		return nil, err
 	}
 
-	return &result, nil
+	return result, nil
 }
 
-// GetUsergroups get all groups for user
-func (client *client) GetUserGroups(token *models.JWT, realm string, userID string) (*[]models.UserGroup, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/users/" + userID + "/groups")
+// GetUsers get all users in realm matching params
+func (client *client) GetUsers(ctx context.Context, token *models.JWT, realm string, params models.GetUsersParams) (*[]models.User, error) {
+	queryParams, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decode into struct
-	var result []models.UserGroup
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	var result []models.User
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/users", queryParams, nil, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
 
-// GetRoleMappingByGroupID gets the role mappings by group
-func (client *client) GetRoleMappingByGroupID(token *models.JWT, realm string, groupID string) (*[]models.RoleMapping, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/groups/" + groupID + "/role-mappings")
-	if err != nil {
+// GetUserCount gets the number of all users in realm; it takes no filters
+func (client *client) GetUserCount(ctx context.Context, token *models.JWT, realm string) (int, error) {
+	var count int
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/users/count", nil, nil, &count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetUsergroups get all groups for user
+func (client *client) GetUserGroups(ctx context.Context, token *models.JWT, realm string, userID string) (*[]models.UserGroup, error) {
+	var result []models.UserGroup
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/users/"+userID+"/groups", nil, nil, &result); err != nil {
 		return nil, err
 	}
 
-	var result []models.RoleMapping
+	return &result, nil
+}
 
-	// Decode into struct
+// GetRoleMappingByGroupID gets the role mappings by group
+func (client *client) GetRoleMappingByGroupID(ctx context.Context, token *models.JWT, realm string, groupID string) (*[]models.RoleMapping, error) {
 	var f map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &f); err != nil {
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/groups/"+groupID+"/role-mappings", nil, nil, &f); err != nil {
 		return nil, err
 	}
 
+	var result []models.RoleMapping
+
 	// JSON object parses into a map with string keys
 	itemsMap := f["clientMappings"].(map[string]interface{})
 
@@ -181,11 +363,11 @@ func (client *client) GetRoleMappingByGroupID(token *models.JWT, realm string, g
 		// The value is an Item, represented as a generic interface
 		case interface{}:
 			jsonClientMapping, _ := json.Marshal(jsonObj)
-			var client models.RoleMapping
-			if err := json.Unmarshal(jsonClientMapping, &client); err != nil {
+			var mapping models.RoleMapping
+			if err := json.Unmarshal(jsonClientMapping, &mapping); err != nil {
 				return nil, err
 			}
-			result = append(result, client)
+			result = append(result, mapping)
 		default:
 			return nil, errors.New("Expecting a JSON object; got something else")
 		}
@@ -194,34 +376,30 @@ func (client *client) GetRoleMappingByGroupID(token *models.JWT, realm string, g
 	return &result, nil
 }
 
-// GetGroups get all groups in realm
-func (client *client) GetGroups(token *models.JWT, realm string) (*[]models.Group, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/groups")
+// GetGroups get all groups in realm matching params
+func (client *client) GetGroups(ctx context.Context, token *models.JWT, realm string, params models.GetGroupsParams) (*[]models.Group, error) {
+	queryParams, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decode into struct
 	var result []models.Group
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/groups", queryParams, nil, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
 
-// GetRoles get all roles in realm
-func (client *client) GetRoles(token *models.JWT, realm string) (*[]models.Role, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/roles")
+// GetRoles get all roles in realm matching params
+func (client *client) GetRoles(ctx context.Context, token *models.JWT, realm string, params models.GetRolesParams) (*[]models.Role, error) {
+	queryParams, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decode into struct
 	var result []models.Role
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/roles", queryParams, nil, &result); err != nil {
 		return nil, err
 	}
 
@@ -229,52 +407,98 @@ func (client *client) GetRoles(token *models.JWT, realm string) (*[]models.Role,
 }
 
 // GetRolesByClientID get all roles for the given client in realm
-func (client *client) GetRolesByClientID(token *models.JWT, realm string, clientID string) (*[]models.Role, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/clients/" + clientID + "/roles")
-	if err != nil {
-		return nil, err
-	}
-
-	log.Println(resp.Status())
-	// Decode into struct
+func (client *client) GetRolesByClientID(ctx context.Context, token *models.JWT, realm string, clientID string) (*[]models.Role, error) {
 	var result []models.Role
-	ioutil.WriteFile("test.json", resp.Body(), 0644)
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/clients/"+clientID+"/roles", nil, nil, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
 
-// GetClients gets all clients in realm
-func (client *client) GetClients(token *models.JWT, realm string) (*[]models.RealmClient, error) {
-	resp, err := getRequestWithHeader(token).
-		Get(client.basePath + "/auth/admin/realms/" + realm + "/clients")
+// GetClients gets all clients in realm matching params
+func (client *client) GetClients(ctx context.Context, token *models.JWT, realm string, params models.GetClientsParams) (*[]models.RealmClient, error) {
+	queryParams, err := query.Values(params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decode into struct
 	var result []models.RealmClient
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/clients", queryParams, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetRequiredActions gets all required actions registered in realm
+func (client *client) GetRequiredActions(ctx context.Context, token *models.JWT, realm string) (*[]models.RequiredActionProviderRepresentation, error) {
+	var result []models.RequiredActionProviderRepresentation
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/authentication/required-actions", nil, nil, &result); err != nil {
 		return nil, err
 	}
 
 	return &result, nil
 }
 
-func getRequestWithHeader(token *models.JWT) *resty.Request {
-	return resty.R().
-		SetHeader("Content-Type", "application/json").
-		SetHeader("Authorization", "Bearer "+token.AccessToken)
+// GetRequiredAction gets a single required action by alias
+func (client *client) GetRequiredAction(ctx context.Context, token *models.JWT, realm string, alias string) (*models.RequiredActionProviderRepresentation, error) {
+	result := &models.RequiredActionProviderRepresentation{}
+	if _, err := client.transport.DoJSON(ctx, token, http.MethodGet, client.basePath+"/auth/admin/realms/"+realm+"/authentication/required-actions/"+alias, nil, nil, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateRequiredAction updates an existing required action, e.g. to enable it or make it default
+func (client *client) UpdateRequiredAction(ctx context.Context, token *models.JWT, realm string, action models.RequiredActionProviderRepresentation) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPut, client.basePath+"/auth/admin/realms/"+realm+"/authentication/required-actions/"+action.Alias, nil, action, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to update required action: " + resp.Status)
+	}
+
+	return nil
+}
+
+// RegisterRequiredAction registers a required action SPI provider with realm so it becomes
+// available for use, e.g. a custom SPI action shipped alongside providerID
+func (client *client) RegisterRequiredAction(ctx context.Context, token *models.JWT, realm string, providerID string, name string) error {
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPost, client.basePath+"/auth/admin/realms/"+realm+"/authentication/register-required-action", nil, map[string]string{
+		"providerId": providerID,
+		"name":       name,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to register required action: " + resp.Status)
+	}
+
+	return nil
 }
 
-func getBasicAuthForClient(clientID string, clientSecret string) string {
-	var httpBasicAuth string
-	if len(clientID) > 0 && len(clientSecret) > 0 {
-		httpBasicAuth = base64.URLEncoding.EncodeToString([]byte(clientID + ":" + clientSecret))
+// ExecuteActionsEmail sends userID an email with a link they can use to execute actions,
+// e.g. VERIFY_EMAIL or UPDATE_PASSWORD, before redirectURI. lifespan is the link's expiry in seconds.
+func (client *client) ExecuteActionsEmail(ctx context.Context, token *models.JWT, realm string, userID string, actions []string, clientID string, redirectURI string, lifespan int) error {
+	queryParams := url.Values{}
+	queryParams.Set("client_id", clientID)
+	queryParams.Set("redirect_uri", redirectURI)
+	queryParams.Set("lifespan", strconv.Itoa(lifespan))
+
+	resp, err := client.transport.DoJSON(ctx, token, http.MethodPut, client.basePath+"/auth/admin/realms/"+realm+"/users/"+userID+"/execute-actions-email", queryParams, actions, nil)
+	if err != nil {
+		return err
 	}
 
-	return "Basic " + httpBasicAuth
-}
\ No newline at end of file
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.New("failed to send required action email: " + resp.Status)
+	}
+
+	return nil
+}
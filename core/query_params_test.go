@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/Nerzal/gocloak/models"
+	"github.com/google/go-querystring/query"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestGetUsersParamsDistinguishesFalseFromUnset(t *testing.T) {
+	values, err := query.Values(models.GetUsersParams{Enabled: boolPtr(false), EmailVerified: boolPtr(false)})
+	if err != nil {
+		t.Fatalf("query.Values returned error: %v", err)
+	}
+
+	if got := values.Get("enabled"); got != "false" {
+		t.Fatalf("expected enabled=false to survive serialization, got %q", got)
+	}
+	if got := values.Get("emailVerified"); got != "false" {
+		t.Fatalf("expected emailVerified=false to survive serialization, got %q", got)
+	}
+
+	unset, err := query.Values(models.GetUsersParams{})
+	if err != nil {
+		t.Fatalf("query.Values returned error: %v", err)
+	}
+	if _, ok := unset["enabled"]; ok {
+		t.Fatal("expected an unset Enabled field to be omitted entirely")
+	}
+	if _, ok := unset["emailVerified"]; ok {
+		t.Fatal("expected an unset EmailVerified field to be omitted entirely")
+	}
+}
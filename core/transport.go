@@ -0,0 +1,290 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Nerzal/gocloak/models"
+)
+
+// Challenge is a parsed WWW-Authenticate header, per RFC 2617 / RFC 6750, e.g.
+// `Bearer realm="master", error="invalid_token"`.
+type Challenge struct {
+	Scheme string
+	Params map[string]string
+}
+
+// TokenRefresher refreshes an access token given a refresh token and the realm it was issued
+// against. Transport calls it to transparently retry a request once after a 401.
+type TokenRefresher func(ctx context.Context, refreshToken string, realm string) (*models.JWT, error)
+
+// Transport centralizes the HTTP concerns shared by every Client call: per-request
+// context, WWW-Authenticate challenge parsing with a transparent single refresh-and-retry,
+// and retry with exponential backoff on 5xx responses and network errors. It is built on
+// net/http and replaces the resty calls that used to live directly in the Client
+// implementation, so the HTTP stack can be swapped without touching the Client interface.
+type Transport struct {
+	httpClient *http.Client
+	maxRetries int
+	retryBase  time.Duration
+	retryMax   time.Duration
+	refresher  TokenRefresher
+}
+
+// TransportOption configures a Transport returned by NewTransport
+type TransportOption func(*Transport)
+
+// WithRoundTripper plugs a custom http.RoundTripper into the Transport, e.g. for mTLS used
+// by x509-authenticated clients or an OpenTelemetry-instrumented transport
+func WithRoundTripper(rt http.RoundTripper) TransportOption {
+	return func(t *Transport) { t.httpClient.Transport = rt }
+}
+
+// WithMaxRetries sets how many times an idempotent request is retried on a 5xx response or
+// network error; POST requests are never retried
+func WithMaxRetries(n int) TransportOption {
+	return func(t *Transport) { t.maxRetries = n }
+}
+
+// WithRetryBackoff sets the base and ceiling durations for the exponential retry backoff
+func WithRetryBackoff(base time.Duration, max time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.retryBase = base
+		t.retryMax = max
+	}
+}
+
+// WithTokenRefresher wires up the callback Transport uses to refresh an expired access
+// token and retry a request once after a 401
+func WithTokenRefresher(refresher TokenRefresher) TransportOption {
+	return func(t *Transport) { t.refresher = refresher }
+}
+
+// NewTransport creates a Transport with sane defaults: 3 retries, 250ms-4s exponential backoff
+func NewTransport(opts ...TransportOption) *Transport {
+	t := &Transport{
+		httpClient: &http.Client{},
+		maxRetries: 3,
+		retryBase:  250 * time.Millisecond,
+		retryMax:   4 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// DoJSON issues a request with a JSON body (nil for none) and decodes a JSON response.
+func (t *Transport) DoJSON(ctx context.Context, token *models.JWT, method string, rawURL string, query url.Values, body interface{}, out interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	resp, respBody, err := t.do(ctx, token, bearerHeader(token), method, rawURL, query, "application/json", reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+// DoForm issues an application/x-www-form-urlencoded request, used by the token endpoints.
+func (t *Transport) DoForm(ctx context.Context, token *models.JWT, method string, rawURL string, form url.Values, out interface{}) (*http.Response, error) {
+	resp, respBody, err := t.do(ctx, token, bearerHeader(token), method, rawURL, nil, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+// DoFormWithBasicAuth issues an application/x-www-form-urlencoded request authenticated with
+// HTTP basic auth (clientID/clientSecret) rather than a bearer token, used by the password
+// grant where the client authenticates itself instead of presenting an existing token. It goes
+// through do/doOnce like every other request, so the same retry rules apply: a POST is not
+// retried on a 5xx response or network error.
+func (t *Transport) DoFormWithBasicAuth(ctx context.Context, clientID string, clientSecret string, method string, rawURL string, form url.Values, out interface{}) (*http.Response, error) {
+	resp, respBody, err := t.do(ctx, nil, basicAuthHeader(clientID, clientSecret), method, rawURL, nil, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+func basicAuthHeader(clientID string, clientSecret string) string {
+	if clientID == "" || clientSecret == "" {
+		return ""
+	}
+
+	return "Basic " + base64.URLEncoding.EncodeToString([]byte(clientID+":"+clientSecret))
+}
+
+func bearerHeader(token *models.JWT) string {
+	if token == nil {
+		return ""
+	}
+
+	return "Bearer " + token.AccessToken
+}
+
+// do executes a request, transparently refreshing token and retrying once on a 401 Bearer
+// challenge, and retrying with exponential backoff on a 5xx response or network error for
+// idempotent methods. POST is never auto-retried: a 5xx can mean the create succeeded and
+// only the acknowledgement was lost, and blindly resending it risks creating the resource twice.
+func (t *Transport) do(ctx context.Context, token *models.JWT, authHeader string, method string, rawURL string, query url.Values, contentType string, body io.Reader) (*http.Response, []byte, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	refreshed := false
+	retryable := isIdempotent(method)
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if bodyBytes != nil {
+			reader = bytes.NewReader(bodyBytes)
+		}
+
+		resp, respBody, err := t.doOnce(ctx, authHeader, method, rawURL, query, contentType, reader)
+		if err == nil && resp.StatusCode < 500 {
+			if resp.StatusCode == http.StatusUnauthorized && !refreshed && token != nil && token.RefreshToken != "" && t.refresher != nil {
+				challenge := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+				if challenge == nil || strings.EqualFold(challenge.Scheme, "Bearer") {
+					refreshedToken, refreshErr := t.refresher(ctx, token.RefreshToken, token.Realm)
+					if refreshErr == nil {
+						token = refreshedToken
+						authHeader = bearerHeader(token)
+						refreshed = true
+						continue
+					}
+				}
+			}
+
+			return resp, respBody, nil
+		}
+
+		if !retryable || attempt >= t.maxRetries {
+			return resp, respBody, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(t.backoff(attempt)):
+		}
+	}
+}
+
+// isIdempotent reports whether method is safe to retry automatically on a 5xx response or
+// network error without risking a duplicate side effect.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *Transport) doOnce(ctx context.Context, authHeader string, method string, rawURL string, query url.Values, contentType string, body io.Reader) (*http.Response, []byte, error) {
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Type", contentType)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, respBody, nil
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	wait := t.retryBase * time.Duration(math.Pow(2, float64(attempt)))
+	if wait > t.retryMax {
+		wait = t.retryMax
+	}
+
+	return wait
+}
+
+// parseChallenge parses a WWW-Authenticate header per RFC 2617 / RFC 6750.
+func parseChallenge(header string) *Challenge {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+
+	parts := strings.SplitN(header, " ", 2)
+	challenge := &Challenge{Scheme: parts[0], Params: map[string]string{}}
+	if len(parts) == 1 {
+		return challenge
+	}
+
+	for _, pair := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		challenge.Params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+
+	return challenge
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/models"
+)
+
+func newTestTransport() *Transport {
+	return NewTransport(WithRetryBackoff(time.Millisecond, time.Millisecond))
+}
+
+func TestDoRetriesIdempotentMethodOn5xx(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := newTestTransport()
+	resp, err := transport.DoJSON(context.Background(), nil, http.MethodGet, server.URL, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoJSON returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls (1 retry), got %d", calls)
+	}
+}
+
+func TestDoDoesNotRetryPostOn5xx(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	transport := newTestTransport()
+	resp, err := transport.DoJSON(context.Background(), nil, http.MethodPost, server.URL, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoJSON returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", resp.StatusCode)
+	}
+	if calls != 1 {
+		t.Fatalf("expected exactly 1 call (no retry for POST), got %d", calls)
+	}
+}
+
+func TestDoRefreshesTokenOnceAgainstIssuingRealm(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer new-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="master"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var refreshedRealm string
+	transport := newTestTransport()
+	transport.refresher = func(ctx context.Context, refreshToken string, realm string) (*models.JWT, error) {
+		refreshedRealm = realm
+		return &models.JWT{AccessToken: "new-token", Realm: realm}, nil
+	}
+
+	token := &models.JWT{AccessToken: "expired-token", RefreshToken: "refresh-token", Realm: "master"}
+	resp, err := transport.DoJSON(context.Background(), token, http.MethodGet, server.URL, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoJSON returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls (original + retry after refresh), got %d", calls)
+	}
+	if refreshedRealm != "master" {
+		t.Fatalf("expected refresher to be called with the token's issuing realm %q, got %q", "master", refreshedRealm)
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type testClaims struct {
+	jwt.StandardClaims
+}
+
+func newTestVerifier(t *testing.T, expectedIssuer string) (TokenVerifier, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: derBytes})
+
+	verifier, err := NewTokenVerifierFromRSAPublicKey("myrealm", "test-kid", expectedIssuer, publicKeyPEM)
+	if err != nil {
+		t.Fatalf("NewTokenVerifierFromRSAPublicKey returned error: %v", err)
+	}
+
+	return verifier, key
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, issuer string, audience string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer,
+		"aud": audience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	token.Header["kid"] = "test-kid"
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign test token: %v", err)
+	}
+
+	return signed
+}
+
+func TestDecodeAccessTokenEnforcesAudience(t *testing.T) {
+	verifier, key := newTestVerifier(t, "")
+	tokenString := signTestToken(t, key, "https://keycloak.example.com/auth/realms/myrealm", "other-client")
+
+	if _, _, err := verifier.DecodeAccessToken(tokenString, "myrealm", "my-client"); err == nil {
+		t.Fatal("expected an error for a token with a non-matching audience, got nil")
+	}
+
+	if _, _, err := verifier.DecodeAccessToken(tokenString, "myrealm", "other-client"); err != nil {
+		t.Fatalf("expected the matching audience to verify, got error: %v", err)
+	}
+}
+
+func TestDecodeAccessTokenCustomClaimsEnforcesIssuer(t *testing.T) {
+	expectedIssuer := "https://keycloak.example.com/auth/realms/myrealm"
+	verifier, key := newTestVerifier(t, expectedIssuer)
+	tokenString := signTestToken(t, key, "https://attacker.example.com/auth/realms/myrealm", "my-client")
+
+	var claims testClaims
+	if err := verifier.DecodeAccessTokenCustomClaims(tokenString, "myrealm", "my-client", &claims); err == nil {
+		t.Fatal("expected an error for a token with a non-matching issuer, got nil")
+	}
+
+	validTokenString := signTestToken(t, key, expectedIssuer, "my-client")
+	if err := verifier.DecodeAccessTokenCustomClaims(validTokenString, "myrealm", "my-client", &claims); err != nil {
+		t.Fatalf("expected the matching issuer to verify, got error: %v", err)
+	}
+}
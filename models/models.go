@@ -0,0 +1,191 @@
+package models
+
+import "encoding/json"
+
+// JWT is the response from Keycloak's token endpoint
+type JWT struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+
+	// Realm is the realm this token was issued against, e.g. "master" for an admin-cli
+	// login used to provision other realms. Not part of Keycloak's response body; Transport
+	// uses it to refresh against the right realm rather than whichever realm a given call targets.
+	Realm string `json:"-"`
+}
+
+// User representation
+type User struct {
+	ID        string `json:"id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Enabled   bool   `json:"enabled,omitempty"`
+}
+
+// UserGroup representation
+type UserGroup struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// RoleMapping representation
+type RoleMapping struct {
+	ID       string `json:"id,omitempty"`
+	Client   string `json:"client,omitempty"`
+	Mappings []Role `json:"mappings,omitempty"`
+}
+
+// Group representation
+type Group struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// Role representation
+type Role struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	ClientRole  bool   `json:"clientRole,omitempty"`
+	ContainerID string `json:"containerId,omitempty"`
+}
+
+// RealmClient representation of a client registered in a realm
+type RealmClient struct {
+	ID       string `json:"id,omitempty"`
+	ClientID string `json:"clientId,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Enabled  bool   `json:"enabled,omitempty"`
+}
+
+// RealmRepresentation representation of a realm
+type RealmRepresentation struct {
+	ID                  string `json:"id,omitempty"`
+	Realm               string `json:"realm,omitempty"`
+	Enabled             bool   `json:"enabled,omitempty"`
+	DisplayName         string `json:"displayName,omitempty"`
+	SslRequired         string `json:"sslRequired,omitempty"`
+	RegistrationAllowed bool   `json:"registrationAllowed,omitempty"`
+}
+
+// ClientAuthType selects how a client authenticates against Keycloak
+type ClientAuthType string
+
+// Supported client authenticator types, mirroring Keycloak's clientAuthenticatorType values
+const (
+	ClientAuthSecret ClientAuthType = "client-secret"
+	ClientAuthJWT    ClientAuthType = "client-jwt"
+	ClientAuthX509   ClientAuthType = "client-x509"
+)
+
+// Client representation of a Keycloak client
+type Client struct {
+	ID                      string            `json:"id,omitempty"`
+	ClientID                string            `json:"clientId,omitempty"`
+	Name                    string            `json:"name,omitempty"`
+	Enabled                 bool              `json:"enabled,omitempty"`
+	ClientAuthenticatorType string            `json:"clientAuthenticatorType,omitempty"`
+	Secret                  string            `json:"secret,omitempty"`
+	RedirectURIs            []string          `json:"redirectUris,omitempty"`
+	PublicClient            bool              `json:"publicClient,omitempty"`
+	ServiceAccountsEnabled  bool              `json:"serviceAccountsEnabled,omitempty"`
+	Attributes              map[string]string `json:"attributes,omitempty"`
+}
+
+// CredentialRepresentation holds a client or user credential, e.g. a client secret
+type CredentialRepresentation struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// GetUsersParams represents the query parameters accepted by GET /users
+type GetUsersParams struct {
+	BriefRepresentation *bool  `url:"briefRepresentation,omitempty"`
+	Email               string `url:"email,omitempty"`
+	EmailVerified       *bool  `url:"emailVerified,omitempty"`
+	Enabled             *bool  `url:"enabled,omitempty"`
+	Exact               *bool  `url:"exact,omitempty"`
+	First               int    `url:"first,omitempty"`
+	FirstName           string `url:"firstName,omitempty"`
+	LastName            string `url:"lastName,omitempty"`
+	IDPAlias            string `url:"idpAlias,omitempty"`
+	IDPUserID           string `url:"idpUserId,omitempty"`
+	Max                 int    `url:"max,omitempty"`
+	Search              string `url:"search,omitempty"`
+	Username            string `url:"username,omitempty"`
+	Q                   string `url:"q,omitempty"`
+}
+
+// GetGroupsParams represents the query parameters accepted by GET /groups
+type GetGroupsParams struct {
+	BriefRepresentation *bool  `url:"briefRepresentation,omitempty"`
+	First               int    `url:"first,omitempty"`
+	Max                 int    `url:"max,omitempty"`
+	Search              string `url:"search,omitempty"`
+}
+
+// GetRolesParams represents the query parameters accepted by GET /roles
+type GetRolesParams struct {
+	BriefRepresentation *bool  `url:"briefRepresentation,omitempty"`
+	First               int    `url:"first,omitempty"`
+	Max                 int    `url:"max,omitempty"`
+	Search              string `url:"search,omitempty"`
+}
+
+// GetClientsParams represents the query parameters accepted by GET /clients
+type GetClientsParams struct {
+	BriefRepresentation *bool  `url:"briefRepresentation,omitempty"`
+	First               int    `url:"first,omitempty"`
+	Max                 int    `url:"max,omitempty"`
+	Search              string `url:"search,omitempty"`
+}
+
+// RequiredActionProviderRepresentation represents a required action a realm can demand
+// of a user before login completes, e.g. VERIFY_EMAIL or UPDATE_PASSWORD
+type RequiredActionProviderRepresentation struct {
+	Alias         string            `json:"alias,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	ProviderID    string            `json:"providerId,omitempty"`
+	Enabled       bool              `json:"enabled,omitempty"`
+	DefaultAction bool              `json:"defaultAction,omitempty"`
+	Priority      int               `json:"priority,omitempty"`
+	Config        map[string]string `json:"config,omitempty"`
+}
+
+// IntrospectTokenResult is the response of the token introspection endpoint
+type IntrospectTokenResult struct {
+	Active    bool   `json:"active"`
+	Exp       int    `json:"exp,omitempty"`
+	Iat       int    `json:"iat,omitempty"`
+	Aud       Aud    `json:"aud,omitempty"`
+	Username  string `json:"username,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+// Aud represents the "aud" claim, which Keycloak encodes as a bare string when the token
+// has a single audience and as an array once more than one client/resource is configured.
+type Aud []string
+
+// UnmarshalJSON accepts both encodings of the "aud" claim.
+func (a *Aud) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Aud{single}
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+
+	*a = Aud(multi)
+	return nil
+}